@@ -0,0 +1,92 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package blockdevice provides access to a block device node.
+package blockdevice
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ReadonlyMode opens a device for reading only.
+	ReadonlyMode = os.O_RDONLY
+	// DefaultMode opens a device for reading and writing.
+	DefaultMode = os.O_RDWR
+)
+
+// Options holds the settings applied by Open.
+type Options struct {
+	// Mode is the os.OpenFile flag the device is opened with.
+	Mode int
+	// Lock is the flock(2) operation (unix.LOCK_EX, unix.LOCK_SH, or 0 for
+	// none) held on the device's file descriptor for the lifetime of the
+	// BlockDevice, released by Close.
+	Lock int
+}
+
+// Option configures Options.
+type Option func(*Options) error
+
+// WithMode sets the os.OpenFile flag Open uses to open the device.
+func WithMode(mode int) Option {
+	return func(o *Options) error {
+		o.Mode = mode
+
+		return nil
+	}
+}
+
+// BlockDevice represents an open block device node.
+type BlockDevice struct {
+	f    *os.File
+	lock int
+}
+
+// Open opens devname, applying opts. By default the device is opened
+// read-write and unlocked; see WithMode, WithExclusiveLock and
+// WithSharedLock.
+func Open(devname string, setters ...Option) (*BlockDevice, error) {
+	opts := &Options{Mode: DefaultMode}
+
+	for _, setter := range setters {
+		if err := setter(opts); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.OpenFile(devname, opts.Mode, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Lock != 0 {
+		if err = unix.Flock(int(f.Fd()), opts.Lock); err != nil {
+			//nolint: errcheck
+			f.Close()
+
+			return nil, err
+		}
+	}
+
+	return &BlockDevice{f: f, lock: opts.Lock}, nil
+}
+
+// Device returns the os.File backing the device node.
+func (bd *BlockDevice) Device() *os.File {
+	return bd.f
+}
+
+// Close releases any advisory lock acquired by Open and closes the
+// device.
+func (bd *BlockDevice) Close() error {
+	if bd.lock != 0 {
+		//nolint: errcheck
+		unix.Flock(int(bd.f.Fd()), unix.LOCK_UN)
+	}
+
+	return bd.f.Close()
+}
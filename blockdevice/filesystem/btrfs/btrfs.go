@@ -0,0 +1,90 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package btrfs probes a block device for a btrfs superblock.
+package btrfs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem"
+)
+
+const (
+	// SuperBlockOffset is the offset of the btrfs superblock on disk.
+	SuperBlockOffset = 65536
+
+	// SuperBlockMagicOffset is the offset of the btrfs magic number,
+	// relative to SuperBlockOffset.
+	SuperBlockMagicOffset = 64
+
+	// SuperBlockUUIDOffset is the offset of the filesystem UUID (`fsid`),
+	// relative to SuperBlockOffset. It precedes the magic number.
+	SuperBlockUUIDOffset = 32
+	// SuperBlockLabelOffset is the offset of the filesystem label, relative
+	// to SuperBlockOffset.
+	SuperBlockLabelOffset = 299
+
+	uuidSize  = 16
+	labelSize = 256
+)
+
+// Magic is the btrfs magic number.
+var Magic = []byte("_BHRfS_M")
+
+func init() {
+	filesystem.Register("btrfs", SuperBlockOffset+SuperBlockMagicOffset, Magic, Decode)
+}
+
+// SuperBlock is a minimal view of the btrfs superblock, enough to identify
+// the filesystem and extract its label and UUID.
+type SuperBlock struct {
+	Magic [8]byte
+	uuid  [uuidSize]byte
+	label [labelSize]byte
+}
+
+// Decode reads a SuperBlock from r.
+func Decode(r io.ReaderAt) (filesystem.SuperBlocker, error) {
+	buf := make([]byte, SuperBlockLabelOffset+labelSize)
+
+	if _, err := r.ReadAt(buf, SuperBlockOffset); err != nil {
+		return nil, err
+	}
+
+	sb := &SuperBlock{}
+
+	copy(sb.Magic[:], buf[SuperBlockMagicOffset:SuperBlockMagicOffset+len(Magic)])
+	copy(sb.uuid[:], buf[SuperBlockUUIDOffset:SuperBlockUUIDOffset+uuidSize])
+	copy(sb.label[:], buf[SuperBlockLabelOffset:SuperBlockLabelOffset+labelSize])
+
+	return sb, nil
+}
+
+// Is implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Is() bool {
+	return bytes.Equal(sb.Magic[:], Magic)
+}
+
+// Offset implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Offset() int64 {
+	return SuperBlockOffset + SuperBlockMagicOffset
+}
+
+// Type implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Type() string {
+	return "btrfs"
+}
+
+// Label implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Label() string {
+	return filesystem.TrimLabel(sb.label[:])
+}
+
+// UUID implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) UUID() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sb.uuid[0:4], sb.uuid[4:6], sb.uuid[6:8], sb.uuid[8:10], sb.uuid[10:16])
+}
@@ -0,0 +1,93 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package ext probes a block device for an ext2/ext3/ext4 superblock.
+package ext
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem"
+)
+
+const (
+	// SuperBlockOffset is the offset of the ext2/3/4 superblock on disk.
+	SuperBlockOffset = 1024
+
+	// SuperBlockMagicOffset is the offset of the ext magic number
+	// (`s_magic`), relative to SuperBlockOffset.
+	SuperBlockMagicOffset = 56
+	// SuperBlockUUIDOffset is the offset of the filesystem UUID (`s_uuid`),
+	// relative to SuperBlockOffset.
+	SuperBlockUUIDOffset = 104
+	// SuperBlockLabelOffset is the offset of the filesystem label
+	// (`s_volume_name`), relative to SuperBlockOffset.
+	SuperBlockLabelOffset = 120
+
+	uuidSize  = 16
+	labelSize = 16
+)
+
+// Magic is the ext2/3/4 magic number (`s_magic`), little-endian.
+var Magic = []byte{0x53, 0xEF}
+
+func init() {
+	filesystem.Register("ext", SuperBlockOffset+SuperBlockMagicOffset, Magic, Decode)
+}
+
+// SuperBlock is a minimal view of the ext2/3/4 superblock, enough to
+// identify the filesystem and extract its label and UUID.
+type SuperBlock struct {
+	Magic [2]byte
+	uuid  [uuidSize]byte
+	label [labelSize]byte
+}
+
+// Decode reads a SuperBlock from r.
+func Decode(r io.ReaderAt) (filesystem.SuperBlocker, error) {
+	buf := make([]byte, SuperBlockLabelOffset+labelSize)
+
+	if _, err := r.ReadAt(buf, SuperBlockOffset); err != nil {
+		return nil, err
+	}
+
+	sb := &SuperBlock{}
+
+	copy(sb.Magic[:], buf[SuperBlockMagicOffset:SuperBlockMagicOffset+2])
+	copy(sb.uuid[:], buf[SuperBlockUUIDOffset:SuperBlockUUIDOffset+uuidSize])
+	copy(sb.label[:], buf[SuperBlockLabelOffset:SuperBlockLabelOffset+labelSize])
+
+	return sb, nil
+}
+
+// Is implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Is() bool {
+	return binary.LittleEndian.Uint16(sb.Magic[:]) == 0xEF53
+}
+
+// Offset implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Offset() int64 {
+	return SuperBlockOffset + SuperBlockMagicOffset
+}
+
+// Type implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Type() string {
+	return "ext4"
+}
+
+// Label implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Label() string {
+	return filesystem.TrimLabel(sb.label[:])
+}
+
+// UUID implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) UUID() string {
+	return formatUUID(sb.uuid)
+}
+
+func formatUUID(b [uuidSize]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,62 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ext_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem/ext"
+)
+
+// fakeExtSuperBlock builds a minimal in-memory image with an ext superblock
+// at the real on-disk offset, for Decode to read without a block device.
+func fakeExtSuperBlock(uuid [16]byte, label string) []byte {
+	buf := make([]byte, ext.SuperBlockOffset+ext.SuperBlockLabelOffset+16)
+
+	copy(buf[ext.SuperBlockOffset+ext.SuperBlockMagicOffset:], ext.Magic)
+	copy(buf[ext.SuperBlockOffset+ext.SuperBlockUUIDOffset:], uuid[:])
+	copy(buf[ext.SuperBlockOffset+ext.SuperBlockLabelOffset:], label)
+
+	return buf
+}
+
+func TestDecode(t *testing.T) {
+	uuid := [16]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+
+	sb, err := ext.Decode(bytes.NewReader(fakeExtSuperBlock(uuid, "boot")))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if !sb.Is() {
+		t.Fatal("Is() = false, want true")
+	}
+
+	if got, want := sb.Type(), "ext4"; got != want {
+		t.Errorf("Type() = %q, want %q", got, want)
+	}
+
+	if got, want := sb.Label(), "boot"; got != want {
+		t.Errorf("Label() = %q, want %q", got, want)
+	}
+
+	if got, want := sb.UUID(), "01020304-0506-0708-090a-0b0c0d0e0f10"; got != want {
+		t.Errorf("UUID() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeNotExt(t *testing.T) {
+	buf := make([]byte, ext.SuperBlockOffset+ext.SuperBlockLabelOffset+16)
+
+	sb, err := ext.Decode(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if sb.Is() {
+		t.Fatal("Is() = true, want false")
+	}
+}
@@ -0,0 +1,114 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package filesystem probes a block device for a known filesystem
+// superblock.
+package filesystem
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// SuperBlocker is the interface implemented by filesystem superblocks that
+// can be matched and decoded by Probe.
+type SuperBlocker interface {
+	// Is reports whether the decoded superblock is valid for its filesystem.
+	Is() bool
+	// Offset is the byte offset on the device of the magic number that
+	// identifies the superblock.
+	Offset() int64
+	// Type is the canonical name of the filesystem (e.g. "ext4", "xfs").
+	Type() string
+	// Label is the filesystem label, if any.
+	Label() string
+	// UUID is the filesystem UUID, if any.
+	UUID() string
+}
+
+// Decoder reads a superblock from r once its magic bytes have matched and
+// returns the decoded SuperBlocker.
+type Decoder func(r io.ReaderAt) (SuperBlocker, error)
+
+type registration struct {
+	name        string
+	magicOffset int64
+	magic       []byte
+	decode      Decoder
+}
+
+// registry holds the set of known filesystem superblocks, in registration
+// order. Probe consults them in order and returns the first match.
+var registry []registration
+
+// Register adds a filesystem superblock decoder to the set consulted by
+// Probe. name identifies the filesystem the decoder detects; it is recorded
+// with the registration but not exposed by this package, so callers learn
+// the type from the decoded superblock's Type. It allows third parties to plug in
+// detection of additional filesystem types without modifying this package.
+func Register(name string, magicOffset int64, magic []byte, decoder Decoder) {
+	registry = append(registry, registration{
+		name:        name,
+		magicOffset: magicOffset,
+		magic:       magic,
+		decode:      decoder,
+	})
+}
+
+// TrimLabel strips the trailing padding from a fixed-size on-disk label or
+// volume name field, so decoders across filesystem types trim labels the
+// same way: up to the first NUL, then any trailing spaces.
+func TrimLabel(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+
+	return string(bytes.TrimRight(b, " "))
+}
+
+// Probe probes the device at devpath for a known filesystem superblock.
+// It returns a nil SuperBlocker and nil error if no known filesystem was
+// found.
+func Probe(devpath string) (SuperBlocker, error) {
+	f, err := os.Open(devpath)
+	if err != nil {
+		return nil, err
+	}
+	//nolint: errcheck
+	defer f.Close()
+
+	return probe(f)
+}
+
+func probe(r io.ReaderAt) (SuperBlocker, error) {
+	buf := make([]byte, 0)
+
+	for _, reg := range registry {
+		if cap(buf) < len(reg.magic) {
+			buf = make([]byte, len(reg.magic))
+		}
+
+		buf = buf[:len(reg.magic)]
+
+		if _, err := r.ReadAt(buf, reg.magicOffset); err != nil {
+			continue
+		}
+
+		if !bytes.Equal(buf, reg.magic) {
+			continue
+		}
+
+		sb, err := reg.decode(r)
+		if err != nil {
+			continue
+		}
+
+		if sb != nil && sb.Is() {
+			return sb, nil
+		}
+	}
+
+	return nil, nil
+}
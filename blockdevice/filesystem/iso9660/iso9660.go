@@ -0,0 +1,84 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package iso9660 probes a block device for an iso9660 primary volume
+// descriptor.
+package iso9660
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem"
+)
+
+const (
+	// SuperBlockOffset is the offset of the primary volume descriptor.
+	SuperBlockOffset = 32768
+
+	// StandardIdentifierOffset is the offset of the standard identifier,
+	// relative to SuperBlockOffset.
+	StandardIdentifierOffset = 1
+	// VolumeIDOffset is the offset of the volume identifier, relative to
+	// SuperBlockOffset.
+	VolumeIDOffset = 40
+
+	volumeIDSize = 32
+)
+
+// Magic is the iso9660 standard identifier.
+var Magic = []byte("CD001")
+
+func init() {
+	filesystem.Register("iso9660", SuperBlockOffset+StandardIdentifierOffset, Magic, Decode)
+}
+
+// SuperBlock is a minimal view of the iso9660 primary volume descriptor,
+// enough to identify the filesystem and extract its volume ID.
+type SuperBlock struct {
+	StandardIdentifier [5]byte
+	VolumeID           [volumeIDSize]byte
+}
+
+// Decode reads a SuperBlock from r.
+func Decode(r io.ReaderAt) (filesystem.SuperBlocker, error) {
+	buf := make([]byte, VolumeIDOffset+volumeIDSize)
+
+	if _, err := r.ReadAt(buf, SuperBlockOffset); err != nil {
+		return nil, err
+	}
+
+	sb := &SuperBlock{}
+
+	copy(sb.StandardIdentifier[:], buf[StandardIdentifierOffset:StandardIdentifierOffset+len(Magic)])
+	copy(sb.VolumeID[:], buf[VolumeIDOffset:VolumeIDOffset+volumeIDSize])
+
+	return sb, nil
+}
+
+// Is implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Is() bool {
+	return bytes.Equal(sb.StandardIdentifier[:], Magic)
+}
+
+// Offset implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Offset() int64 {
+	return SuperBlockOffset + StandardIdentifierOffset
+}
+
+// Type implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Type() string {
+	return "iso9660"
+}
+
+// Label implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Label() string {
+	return filesystem.TrimLabel(sb.VolumeID[:])
+}
+
+// UUID implements the filesystem.SuperBlocker interface. iso9660 has no
+// UUID, so it always returns the empty string.
+func (sb *SuperBlock) UUID() string {
+	return ""
+}
@@ -0,0 +1,93 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package luks probes a block device for a LUKS1 or LUKS2 header.
+//
+// LUKS1 and LUKS2 share the same magic and differ only in their version
+// field, so both are decoded here and exposed as distinct filesystem
+// types ("luks" and "luks2").
+package luks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem"
+)
+
+const (
+	// SuperBlockMagicOffset is the offset of the LUKS magic number.
+	SuperBlockMagicOffset = 0
+
+	versionOffset = 6
+	// SuperBlockUUIDOffset is the offset of the volume UUID, relative to
+	// SuperBlockMagicOffset.
+	SuperBlockUUIDOffset = 168
+
+	uuidSize = 40
+)
+
+// Magic is the LUKS1/LUKS2 magic number.
+var Magic = []byte{'L', 'U', 'K', 'S', 0xba, 0xbe}
+
+func init() {
+	filesystem.Register("luks", SuperBlockMagicOffset, Magic, Decode)
+}
+
+// SuperBlock is a minimal view of the LUKS1/LUKS2 header, enough to
+// identify the format and its volume UUID.
+type SuperBlock struct {
+	Magic   [6]byte
+	Version uint16
+	uuid    [uuidSize]byte
+}
+
+// Decode reads a SuperBlock from r.
+func Decode(r io.ReaderAt) (filesystem.SuperBlocker, error) {
+	buf := make([]byte, SuperBlockUUIDOffset+uuidSize)
+
+	if _, err := r.ReadAt(buf, SuperBlockMagicOffset); err != nil {
+		return nil, err
+	}
+
+	sb := &SuperBlock{}
+
+	copy(sb.Magic[:], buf[:len(Magic)])
+	sb.Version = binary.BigEndian.Uint16(buf[versionOffset : versionOffset+2])
+	copy(sb.uuid[:], buf[SuperBlockUUIDOffset:SuperBlockUUIDOffset+uuidSize])
+
+	return sb, nil
+}
+
+// Is implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Is() bool {
+	return bytes.Equal(sb.Magic[:], Magic) && (sb.Version == 1 || sb.Version == 2)
+}
+
+// Offset implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Offset() int64 {
+	return SuperBlockMagicOffset
+}
+
+// Type implements the filesystem.SuperBlocker interface. It reports "luks"
+// for a LUKS1 header and "luks2" for a LUKS2 header.
+func (sb *SuperBlock) Type() string {
+	if sb.Version == 2 {
+		return "luks2"
+	}
+
+	return "luks"
+}
+
+// Label implements the filesystem.SuperBlocker interface. LUKS headers
+// carry no label, so it always returns the empty string.
+func (sb *SuperBlock) Label() string {
+	return ""
+}
+
+// UUID implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) UUID() string {
+	return filesystem.TrimLabel(sb.uuid[:])
+}
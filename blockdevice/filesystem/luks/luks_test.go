@@ -0,0 +1,70 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package luks_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem/luks"
+)
+
+// fakeLuksHeader builds a minimal in-memory image with a LUKS header at
+// the real on-disk offset, for Decode to read without a block device.
+func fakeLuksHeader(version uint16, uuid string) []byte {
+	buf := make([]byte, luks.SuperBlockUUIDOffset+40)
+
+	copy(buf, luks.Magic)
+	binary.BigEndian.PutUint16(buf[6:8], version)
+	copy(buf[luks.SuperBlockUUIDOffset:], uuid)
+
+	return buf
+}
+
+func TestDecodeLUKS1(t *testing.T) {
+	sb, err := luks.Decode(bytes.NewReader(fakeLuksHeader(1, "dead-beef")))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if !sb.Is() {
+		t.Fatal("Is() = false, want true")
+	}
+
+	if got, want := sb.Type(), "luks"; got != want {
+		t.Errorf("Type() = %q, want %q", got, want)
+	}
+
+	if got, want := sb.UUID(), "dead-beef"; got != want {
+		t.Errorf("UUID() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeLUKS2(t *testing.T) {
+	sb, err := luks.Decode(bytes.NewReader(fakeLuksHeader(2, "dead-beef")))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if !sb.Is() {
+		t.Fatal("Is() = false, want true")
+	}
+
+	if got, want := sb.Type(), "luks2"; got != want {
+		t.Errorf("Type() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeBadVersion(t *testing.T) {
+	sb, err := luks.Decode(bytes.NewReader(fakeLuksHeader(3, "dead-beef")))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if sb.Is() {
+		t.Fatal("Is() = true, want false for an unrecognised LUKS version")
+	}
+}
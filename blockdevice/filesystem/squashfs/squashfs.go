@@ -0,0 +1,78 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package squashfs probes a block device for a squashfs superblock.
+//
+// squashfs carries no label or UUID; detecting it is still useful so
+// callers can tell a squashfs image apart from an unknown/unformatted
+// device.
+package squashfs
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem"
+)
+
+// SuperBlockMagicOffset is the offset of the squashfs magic number.
+const SuperBlockMagicOffset = 0
+
+// MagicLE and MagicBE are the two byte orders squashfs may be written in.
+var (
+	MagicLE = []byte("hsqs")
+	MagicBE = []byte("sqsh")
+)
+
+func init() {
+	filesystem.Register("squashfs", SuperBlockMagicOffset, MagicLE, Decode)
+	filesystem.Register("squashfs", SuperBlockMagicOffset, MagicBE, Decode)
+}
+
+// SuperBlock is a minimal view of the squashfs superblock, enough to
+// identify the filesystem.
+type SuperBlock struct {
+	Magic [4]byte
+}
+
+// Decode reads a SuperBlock from r.
+func Decode(r io.ReaderAt) (filesystem.SuperBlocker, error) {
+	buf := make([]byte, 4)
+
+	if _, err := r.ReadAt(buf, SuperBlockMagicOffset); err != nil {
+		return nil, err
+	}
+
+	sb := &SuperBlock{}
+	copy(sb.Magic[:], buf)
+
+	return sb, nil
+}
+
+// Is implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Is() bool {
+	return bytes.Equal(sb.Magic[:], MagicLE) || bytes.Equal(sb.Magic[:], MagicBE)
+}
+
+// Offset implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Offset() int64 {
+	return SuperBlockMagicOffset
+}
+
+// Type implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Type() string {
+	return "squashfs"
+}
+
+// Label implements the filesystem.SuperBlocker interface. squashfs has no
+// label, so it always returns the empty string.
+func (sb *SuperBlock) Label() string {
+	return ""
+}
+
+// UUID implements the filesystem.SuperBlocker interface. squashfs has no
+// UUID, so it always returns the empty string.
+func (sb *SuperBlock) UUID() string {
+	return ""
+}
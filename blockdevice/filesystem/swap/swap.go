@@ -0,0 +1,70 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package swap probes a block device for a Linux swap signature.
+package swap
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem"
+)
+
+// SuperBlockMagicOffset is the offset of the swap signature, ten bytes
+// before the end of the page that holds the swap header.
+var SuperBlockMagicOffset = int64(os.Getpagesize() - len(Magic))
+
+// Magic is the Linux swap v2 signature.
+var Magic = []byte("SWAPSPACE2")
+
+func init() {
+	filesystem.Register("swap", SuperBlockMagicOffset, Magic, Decode)
+}
+
+// SuperBlock identifies a Linux swap partition. Swap carries no label or
+// UUID in the region this package reads.
+type SuperBlock struct {
+	Magic [10]byte
+}
+
+// Decode reads a SuperBlock from r.
+func Decode(r io.ReaderAt) (filesystem.SuperBlocker, error) {
+	buf := make([]byte, len(Magic))
+
+	if _, err := r.ReadAt(buf, SuperBlockMagicOffset); err != nil {
+		return nil, err
+	}
+
+	sb := &SuperBlock{}
+	copy(sb.Magic[:], buf)
+
+	return sb, nil
+}
+
+// Is implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Is() bool {
+	return bytes.Equal(sb.Magic[:], Magic)
+}
+
+// Offset implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Offset() int64 {
+	return SuperBlockMagicOffset
+}
+
+// Type implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Type() string {
+	return "swap"
+}
+
+// Label implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Label() string {
+	return ""
+}
+
+// UUID implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) UUID() string {
+	return ""
+}
@@ -0,0 +1,51 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package swap_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem/swap"
+)
+
+// fakeSwapHeader builds a minimal in-memory image with the swap signature
+// at the real on-disk offset (pagesize-10), for Decode to read without a
+// block device.
+func fakeSwapHeader() []byte {
+	buf := make([]byte, swap.SuperBlockMagicOffset+int64(len(swap.Magic)))
+
+	copy(buf[swap.SuperBlockMagicOffset:], swap.Magic)
+
+	return buf
+}
+
+func TestDecode(t *testing.T) {
+	sb, err := swap.Decode(bytes.NewReader(fakeSwapHeader()))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if !sb.Is() {
+		t.Fatal("Is() = false, want true")
+	}
+
+	if got, want := sb.Type(), "swap"; got != want {
+		t.Errorf("Type() = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeNotSwap(t *testing.T) {
+	buf := make([]byte, swap.SuperBlockMagicOffset+int64(len(swap.Magic)))
+
+	sb, err := swap.Decode(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if sb.Is() {
+		t.Fatal("Is() = true, want false")
+	}
+}
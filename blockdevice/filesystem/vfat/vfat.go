@@ -0,0 +1,93 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package vfat probes a block device for a FAT12/16/32 boot sector.
+package vfat
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem"
+)
+
+const labelSize = 11
+
+// variant describes where a given FAT flavour's filesystem-type string and
+// volume label live in the boot sector; FAT32 moved both further into the
+// sector to make room for its extended BIOS parameter block.
+type variant struct {
+	magicOffset int64
+	magic       []byte
+	labelOffset int64
+}
+
+var variants = []variant{
+	{82, []byte("FAT32   "), 71},
+	{54, []byte("FAT16   "), 43},
+	{54, []byte("FAT12   "), 43},
+}
+
+func init() {
+	for _, v := range variants {
+		filesystem.Register("vfat", v.magicOffset, v.magic, decoder(v))
+	}
+}
+
+// SuperBlock is a minimal view of a FAT boot sector, enough to identify
+// the filesystem and extract its volume label.
+type SuperBlock struct {
+	Magic       [8]byte
+	label       [labelSize]byte
+	magicOffset int64
+}
+
+func decoder(v variant) filesystem.Decoder {
+	return func(r io.ReaderAt) (filesystem.SuperBlocker, error) {
+		size := v.labelOffset + labelSize
+		if end := v.magicOffset + int64(len(v.magic)); end > size {
+			size = end
+		}
+
+		buf := make([]byte, size)
+
+		if _, err := r.ReadAt(buf, 0); err != nil {
+			return nil, err
+		}
+
+		sb := &SuperBlock{magicOffset: v.magicOffset}
+
+		copy(sb.Magic[:], buf[v.magicOffset:v.magicOffset+int64(len(v.magic))])
+		copy(sb.label[:], buf[v.labelOffset:v.labelOffset+labelSize])
+
+		return sb, nil
+	}
+}
+
+// Is implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Is() bool {
+	return bytes.HasPrefix(sb.Magic[:], []byte("FAT"))
+}
+
+// Offset implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Offset() int64 {
+	return sb.magicOffset
+}
+
+// Type implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Type() string {
+	return "vfat"
+}
+
+// Label implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Label() string {
+	return filesystem.TrimLabel(sb.label[:])
+}
+
+// UUID implements the filesystem.SuperBlocker interface. The volume serial
+// number in the FAT boot sector is not a UUID, so this always returns the
+// empty string.
+func (sb *SuperBlock) UUID() string {
+	return ""
+}
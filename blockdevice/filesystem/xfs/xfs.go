@@ -0,0 +1,84 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package xfs probes a block device for an xfs superblock.
+package xfs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem"
+)
+
+const (
+	// SuperBlockOffset is the offset of the xfs superblock.
+	SuperBlockOffset = 0
+
+	// UUIDOffset is the offset of the filesystem UUID (`sb_uuid`).
+	UUIDOffset = 32
+	// FnameOffset is the offset of the filesystem label (`sb_fname`).
+	FnameOffset = 108
+
+	uuidSize  = 16
+	fnameSize = 12
+)
+
+// Magic is the xfs magic number (`XFSB`).
+var Magic = []byte("XFSB")
+
+func init() {
+	filesystem.Register("xfs", SuperBlockOffset, Magic, Decode)
+}
+
+// SuperBlock is a minimal view of the xfs superblock, enough to identify
+// the filesystem and extract its label and UUID.
+type SuperBlock struct {
+	Magic [4]byte
+	uuid  [uuidSize]byte
+	Fname [fnameSize]byte
+}
+
+// Decode reads a SuperBlock from r.
+func Decode(r io.ReaderAt) (filesystem.SuperBlocker, error) {
+	buf := make([]byte, FnameOffset+fnameSize)
+
+	if _, err := r.ReadAt(buf, SuperBlockOffset); err != nil {
+		return nil, err
+	}
+
+	sb := &SuperBlock{}
+
+	copy(sb.Magic[:], buf[:len(Magic)])
+	copy(sb.uuid[:], buf[UUIDOffset:UUIDOffset+uuidSize])
+	copy(sb.Fname[:], buf[FnameOffset:FnameOffset+fnameSize])
+
+	return sb, nil
+}
+
+// Is implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Is() bool {
+	return bytes.Equal(sb.Magic[:], Magic)
+}
+
+// Offset implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Offset() int64 {
+	return SuperBlockOffset
+}
+
+// Type implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Type() string {
+	return "xfs"
+}
+
+// Label implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) Label() string {
+	return filesystem.TrimLabel(sb.Fname[:])
+}
+
+// UUID implements the filesystem.SuperBlocker interface.
+func (sb *SuperBlock) UUID() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sb.uuid[0:4], sb.uuid[4:6], sb.uuid[6:8], sb.uuid[8:10], sb.uuid[10:16])
+}
@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package blockdevice
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// WithLock requests that the device be opened with the given flock(2)
+// operation (LOCK_EX or LOCK_SH) held for the lifetime of the returned
+// BlockDevice, released when it is closed.
+func WithLock(operation int) Option {
+	return func(o *Options) error {
+		o.Lock = operation
+
+		return nil
+	}
+}
+
+// WithExclusiveLock requests that the device be opened with an exclusive
+// (flock LOCK_EX) advisory lock held for the lifetime of the returned
+// BlockDevice, released when it is closed. Use it around partition-table
+// writes to avoid racing udevd's BLKRRPART trigger while the GPT is being
+// rewritten.
+func WithExclusiveLock() Option {
+	return WithLock(unix.LOCK_EX)
+}
+
+// WithSharedLock requests a shared (flock LOCK_SH) advisory lock, enough
+// for a read-only probe to exclude concurrent writers without blocking
+// other readers.
+func WithSharedLock() Option {
+	return WithLock(unix.LOCK_SH)
+}
+
+// WithExclusiveLock runs fn while holding an exclusive (LOCK_EX) advisory
+// lock on the device node. When fn returns, the lock taken by Open, if any,
+// is restored; otherwise the device is unlocked. It
+// gives library users a portable critical section for partition-table
+// writes, instead of reimplementing the locking dance around every
+// PartitionTable().Write() call.
+func (bd *BlockDevice) WithExclusiveLock(fn func() error) error {
+	fd := int(bd.f.Fd())
+
+	if err := unix.Flock(fd, unix.LOCK_EX); err != nil {
+		return err
+	}
+	defer func() {
+		if bd.lock != 0 {
+			//nolint: errcheck
+			unix.Flock(fd, bd.lock)
+
+			return
+		}
+
+		//nolint: errcheck
+		unix.Flock(fd, unix.LOCK_UN)
+	}()
+
+	return fn()
+}
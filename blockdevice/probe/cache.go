@@ -0,0 +1,120 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package probe
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// cacheKey captures the sysfs state a cache entry was computed from, so a
+// device that is resized or replaced invalidates itself naturally.
+type cacheKey struct {
+	mtime int64
+	size  int64
+}
+
+type cacheEntry struct {
+	key   cacheKey
+	paths []probedPath
+}
+
+// Cache memoises the set of device/partition paths found to carry a
+// filesystem under a given devpath, so that repeated probes of the same
+// device - for example an installer looking up boot, ephemeral, and state
+// partitions in sequence - don't re-read every superblock on every call.
+//
+// Entries are keyed on the devpath's sysfs modification time and sector
+// count, so a cache entry is used only as long as the underlying device
+// has not changed.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewCache creates an empty Cache.
+func NewCache() *Cache {
+	return &Cache{entries: map[string]cacheEntry{}}
+}
+
+// Get returns the cached paths for devpath, if an entry exists and the
+// device's sysfs mtime and size have not changed since it was cached.
+func (c *Cache) Get(devpath string) ([]probedPath, bool) {
+	key, err := statKey(devpath)
+	if err != nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[devpath]
+	if !ok || entry.key != key {
+		return nil, false
+	}
+
+	return entry.paths, true
+}
+
+// Put stores paths for devpath, keyed by its current sysfs mtime and size.
+func (c *Cache) Put(devpath string, paths []probedPath) error {
+	key, err := statKey(devpath)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[devpath] = cacheEntry{key: key, paths: paths}
+
+	return nil
+}
+
+// Invalidate removes any cached entry for devpath, forcing the next probe
+// of it to re-read its superblocks.
+func (c *Cache) Invalidate(devpath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, devpath)
+}
+
+// defaultCache backs the package-level probing functions (All,
+// GetDevWithPartitionName, GetDevWithFileSystemLabel, ...).
+var defaultCache = NewCache()
+
+// InvalidateCache drops any cached probe result for devpath from the
+// package-level cache, so that a caller who just repartitioned or
+// reformatted a device can force the next probe of it to re-read its
+// superblocks rather than reusing a stale result.
+func InvalidateCache(devpath string) {
+	defaultCache.Invalidate(devpath)
+}
+
+func statKey(devpath string) (cacheKey, error) {
+	node := filepath.Base(devpath)
+
+	info, err := os.Stat(filepath.Join("/sys/block", node))
+	if err != nil {
+		return cacheKey{}, err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join("/sys/block", node, "size"))
+	if err != nil {
+		return cacheKey{}, err
+	}
+
+	size, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return cacheKey{}, err
+	}
+
+	return cacheKey{mtime: info.ModTime().UnixNano(), size: size}, nil
+}
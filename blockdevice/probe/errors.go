@@ -0,0 +1,15 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package probe
+
+import "errors"
+
+// ErrDeviceHeld is returned when a device is claimed by another kernel
+// driver (device-mapper, md, LVM, ...) and must not be opened for writing.
+var ErrDeviceHeld = errors.New("device is held by another driver")
+
+// ErrDeviceMounted is returned when a device, or one of its partitions,
+// is currently mounted.
+var ErrDeviceMounted = errors.New("device is mounted")
@@ -5,17 +5,24 @@
 package probe
 
 import (
-	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
 
 	"github.com/talos-systems/go-blockdevice/blockdevice"
 	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem"
-	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem/iso9660"
-	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem/vfat"
-	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem/xfs"
+	_ "github.com/talos-systems/go-blockdevice/blockdevice/filesystem/btrfs"    // register the btrfs superblock decoder
+	_ "github.com/talos-systems/go-blockdevice/blockdevice/filesystem/ext"      // register the ext2/3/4 superblock decoder
+	_ "github.com/talos-systems/go-blockdevice/blockdevice/filesystem/iso9660"  // register the iso9660 superblock decoder
+	_ "github.com/talos-systems/go-blockdevice/blockdevice/filesystem/luks"     // register the LUKS1/LUKS2 superblock decoder
+	_ "github.com/talos-systems/go-blockdevice/blockdevice/filesystem/squashfs" // register the squashfs superblock decoder
+	_ "github.com/talos-systems/go-blockdevice/blockdevice/filesystem/swap"     // register the swap superblock decoder
+	_ "github.com/talos-systems/go-blockdevice/blockdevice/filesystem/vfat"     // register the vfat superblock decoder
+	_ "github.com/talos-systems/go-blockdevice/blockdevice/filesystem/xfs"      // register the xfs superblock decoder
 	"github.com/talos-systems/go-blockdevice/blockdevice/partition/gpt"
 	"github.com/talos-systems/go-blockdevice/blockdevice/util"
 )
@@ -26,9 +33,16 @@ type ProbedBlockDevice struct {
 
 	SuperBlock filesystem.SuperBlocker
 	Path       string
+	// Parent is the path of the disk Path was found on - itself, if Path
+	// has no partition table.
+	Parent string
 }
 
 // SelectOption is a callback matcher for All block devices probes.
+//
+// all() invokes matchers from one goroutine per probed device, so a
+// SelectOption that keeps state across calls (as WithSingleResult does)
+// must synchronize its own access to that state.
 type SelectOption func(device *ProbedBlockDevice) (bool, error)
 
 // WithPartitionLabel search for a block device which has partitions with some specific label.
@@ -52,36 +66,29 @@ func WithFileSystemLabel(label string) SelectOption {
 			return false, err
 		}
 
-		if superblock != nil {
-			switch sb := superblock.(type) {
-			case *iso9660.SuperBlock:
-				trimmed := bytes.Trim(sb.VolumeID[:], " \x00")
-				if bytes.Equal(trimmed, []byte(label)) {
-					return true, nil
-				}
-			case *vfat.SuperBlock:
-				trimmed := bytes.Trim(sb.Label[:], " \x00")
-				if bytes.Equal(trimmed, []byte(label)) {
-					return true, nil
-				}
-			case *xfs.SuperBlock:
-				trimmed := bytes.Trim(sb.Fname[:], " \x00")
-				if bytes.Equal(trimmed, []byte(label)) {
-					return true, nil
-				}
-			}
+		return superblock != nil && superblock.Label() == label, nil
+	}
+}
+
+// WithFileSystemUUID search for a block device which has filesystem on root level
+// and that filesystem's UUID matches the provided UUID.
+func WithFileSystemUUID(uuid string) SelectOption {
+	return func(device *ProbedBlockDevice) (bool, error) {
+		superblock, err := filesystem.Probe(device.Device().Name())
+		if err != nil {
+			return false, err
 		}
 
-		return false, nil
+		return superblock != nil && superblock.UUID() == uuid, nil
 	}
 }
 
 // WithSingleResult enforces a single result from All function.
 func WithSingleResult() SelectOption {
-	count := 0
+	var count int32
 
 	return func(device *ProbedBlockDevice) (bool, error) {
-		if count > 0 {
+		if atomic.AddInt32(&count, 1) > 1 {
 			return false, fmt.Errorf("got more than one blockdevice with provided criteria")
 		}
 
@@ -89,7 +96,25 @@ func WithSingleResult() SelectOption {
 	}
 }
 
+// WorkerPoolSize bounds the number of goroutines all() fans out across the
+// entries of /sys/block. It defaults to the number of logical CPUs and can
+// be lowered on systems where probing many devices concurrently causes
+// I/O contention.
+var WorkerPoolSize = runtime.NumCPU()
+
+type scanResult struct {
+	devices []*ProbedBlockDevice
+	err     error
+}
+
 // all probes a block device's file system for the given label.
+//
+// One goroutine is started per entry of /sys/block, bounded by
+// WorkerPoolSize, so that probing many disks doesn't serialise on the
+// slowest one. Results are collected into a slice indexed by each entry's
+// position in the (lexically sorted) ioutil.ReadDir output rather than by
+// completion order, so that the returned devices come back in the same
+// order on every run.
 func all(mode int, options ...SelectOption) (all []*ProbedBlockDevice, err error) {
 	var infos []os.FileInfo
 
@@ -97,45 +122,129 @@ func all(mode int, options ...SelectOption) (all []*ProbedBlockDevice, err error
 		return nil, err
 	}
 
-	for _, info := range infos {
-		devpath := "/dev/" + info.Name()
+	results := make([]scanResult, len(infos))
+	sem := make(chan struct{}, WorkerPoolSize)
+
+	var wg sync.WaitGroup
+
+	for i, info := range infos {
+		wg.Add(1)
+
+		go func(i int, devpath string) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = scanAndSelect(devpath, mode, options)
+		}(i, "/dev/"+info.Name())
+	}
+
+	wg.Wait()
+
+	for _, res := range results {
+		if res.err != nil {
+			err = res.err
+
+			continue
+		}
+
+		all = append(all, res.devices...)
+	}
+
+	if err != nil {
+		for _, dev := range all {
+			//nolint: errcheck
+			dev.Close()
+		}
+
+		return nil, err
+	}
+
+	return all, nil
+}
+
+// scanAndSelect probes devpath and applies options to each resulting
+// ProbedBlockDevice, closing those that don't match. On error it closes
+// every device it has opened - matched, rejected-but-unclosed, and not yet
+// visited - before returning, so a failing matcher never leaks fds.
+func scanAndSelect(devpath string, mode int, options []SelectOption) scanResult {
+	// A shared lock is enough here: these are read-only probes run
+	// concurrently across /sys/block, and LOCK_SH only needs to exclude a
+	// concurrent partition-table write, not other readers.
+	probed := probePartitions(devpath, blockdevice.WithMode(mode), blockdevice.WithSharedLock())
 
-		probed := probePartitions(devpath, blockdevice.WithMode(mode))
+	var matched []*ProbedBlockDevice
 
-		for _, dev := range probed {
-			add := true
-			for _, matches := range options {
-				add, err = matches(dev)
-				if err != nil {
-					if e := dev.Close(); e != nil {
-						return nil, e
-					}
+	for i, dev := range probed {
+		add := true
 
-					return nil, err
-				}
+		for _, matches := range options {
+			var err error
 
-				if !add {
-					err = dev.Close()
-					if err != nil {
-						return nil, err
-					}
+			add, err = matches(dev)
+			if err != nil {
+				closeAll(matched)
+				closeAll(probed[i:])
 
-					break
-				}
+				return scanResult{err: err}
 			}
 
-			if add {
-				all = append(all, dev)
+			if !add {
+				break
 			}
 		}
+
+		if add {
+			matched = append(matched, dev)
+		} else if err := dev.Close(); err != nil {
+			closeAll(matched)
+			closeAll(probed[i+1:])
+
+			return scanResult{err: err}
+		}
 	}
 
-	return all, nil
+	return scanResult{devices: matched}
+}
+
+// closeAll closes every device in devices, ignoring errors - used on error
+// paths where the original error already takes precedence.
+func closeAll(devices []*ProbedBlockDevice) {
+	for _, dev := range devices {
+		//nolint: errcheck
+		dev.Close()
+	}
 }
 
 // DevForPartitionLabel finds and opens partition as a blockdevice.
-func DevForPartitionLabel(devname, label string) (*blockdevice.BlockDevice, error) {
-	bd, err := blockdevice.Open(devname)
+//
+// The device is refused if it is held by another kernel driver (device-mapper,
+// md, LVM, ...) or currently mounted, so that destructive partition-table
+// operations never race with an in-use disk. Pass blockdevice.WithExclusiveLock()
+// to additionally hold a flock(LOCK_EX) for the lifetime of the returned
+// BlockDevice, excluding udevd's BLKRRPART trigger while the caller rewrites
+// the partition table.
+func DevForPartitionLabel(devname, label string, opts ...blockdevice.Option) (*blockdevice.BlockDevice, error) {
+	held, err := isHeld(devname)
+	if err != nil {
+		return nil, err
+	}
+
+	if held {
+		return nil, ErrDeviceHeld
+	}
+
+	mounted, err := isMounted(devname)
+	if err != nil {
+		return nil, err
+	}
+
+	if mounted {
+		return nil, ErrDeviceMounted
+	}
+
+	bd, err := blockdevice.Open(devname, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -143,8 +252,16 @@ func DevForPartitionLabel(devname, label string) (*blockdevice.BlockDevice, erro
 	return bd.OpenPartition(label)
 }
 
-func probe(devpath string) (devpaths []string) {
-	devpaths = []string{}
+// probedPath pairs a path discovered by probe with the superblock already
+// decoded while discovering it, so a cache hit on the result can skip
+// re-reading the superblock entirely.
+type probedPath struct {
+	path string
+	sb   filesystem.SuperBlocker
+}
+
+func probe(devpath string) (devpaths []probedPath) {
+	devpaths = []probedPath{}
 
 	// Start by opening the block device.
 	// If a partition table was not found, it is still possible that a
@@ -153,7 +270,7 @@ func probe(devpath string) (devpaths []string) {
 	if err != nil {
 		//nolint: errcheck
 		if sb, _ := filesystem.Probe(devpath); sb != nil {
-			devpaths = append(devpaths, devpath)
+			devpaths = append(devpaths, probedPath{path: devpath, sb: sb})
 		}
 
 		return devpaths
@@ -168,7 +285,7 @@ func probe(devpath string) (devpaths []string) {
 	if err != nil {
 		//nolint: errcheck
 		if sb, _ := filesystem.Probe(devpath); sb != nil {
-			devpaths = append(devpaths, devpath)
+			devpaths = append(devpaths, probedPath{path: devpath, sb: sb})
 		}
 
 		return devpaths
@@ -185,7 +302,7 @@ func probe(devpath string) (devpaths []string) {
 
 		//nolint: errcheck
 		if sb, _ := filesystem.Probe(partpath); sb != nil {
-			devpaths = append(devpaths, partpath)
+			devpaths = append(devpaths, probedPath{path: partpath, sb: sb})
 		}
 	}
 
@@ -242,7 +359,7 @@ func GetDevPathWithFileSystemLabel(value string) (path string, err error) {
 // GetPartitionWithName probes all known block device's partition
 // table for a parition with the specified name.
 //
-//nolint: gocyclo
+//nolint:gocyclo
 func GetPartitionWithName(name string) (part *gpt.Partition, err error) {
 	device, err := GetDevWithPartitionName(name)
 	if err != nil {
@@ -252,20 +369,28 @@ func GetPartitionWithName(name string) (part *gpt.Partition, err error) {
 	return device.GetPartition(name)
 }
 
+// cachedProbe is probe, memoised in defaultCache.
+func cachedProbe(devpath string) []probedPath {
+	if paths, ok := defaultCache.Get(devpath); ok {
+		return paths
+	}
+
+	paths := probe(devpath)
+
+	//nolint: errcheck
+	defaultCache.Put(devpath, paths)
+
+	return paths
+}
+
 func probePartitions(devpath string, opts ...blockdevice.Option) (probed []*ProbedBlockDevice) {
-	for _, path := range probe(devpath) {
-		var (
-			bd  *blockdevice.BlockDevice
-			sb  filesystem.SuperBlocker
-			err error
-		)
-
-		bd, err = blockdevice.Open(devpath, opts...)
+	for _, p := range cachedProbe(devpath) {
+		bd, err := blockdevice.Open(devpath, opts...)
 		if err != nil {
 			continue
 		}
 
-		probed = append(probed, &ProbedBlockDevice{BlockDevice: bd, SuperBlock: sb, Path: path})
+		probed = append(probed, &ProbedBlockDevice{BlockDevice: bd, SuperBlock: p.sb, Path: p.path, Parent: devpath})
 	}
 
 	return probed
@@ -0,0 +1,36 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package probe
+
+import "path/filepath"
+
+// byPath is the root of the udev-managed symlink trees that index block
+// devices by label, UUID, partition label, partition UUID, ID and path.
+const byPath = "/dev/disk"
+
+// resolveDevicePath resolves devpath, whether it is a udev-style symlink
+// under /dev/disk/by-* or any other path, down to its canonical /dev/<node>
+// path.
+func resolveDevicePath(devpath string) (string, error) {
+	return filepath.EvalSymlinks(devpath)
+}
+
+// ByUUID resolves a filesystem UUID directly via /dev/disk/by-uuid,
+// avoiding a scan of /sys/block.
+func ByUUID(uuid string) (string, error) {
+	return resolveDevicePath(filepath.Join(byPath, "by-uuid", uuid))
+}
+
+// ByPartUUID resolves a partition UUID directly via /dev/disk/by-partuuid,
+// avoiding a scan of /sys/block.
+func ByPartUUID(uuid string) (string, error) {
+	return resolveDevicePath(filepath.Join(byPath, "by-partuuid", uuid))
+}
+
+// ByPartLabel resolves a partition label directly via
+// /dev/disk/by-partlabel, avoiding a scan of /sys/block.
+func ByPartLabel(label string) (string, error) {
+	return resolveDevicePath(filepath.Join(byPath, "by-partlabel", label))
+}
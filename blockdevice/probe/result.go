@@ -0,0 +1,133 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package probe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice"
+	"github.com/talos-systems/go-blockdevice/blockdevice/partition/gpt"
+	"github.com/talos-systems/go-blockdevice/blockdevice/util"
+)
+
+// ProbeResult is a flat, JSON-friendly summary of one probed device or
+// partition, modelled after `blkid -o export` output, so that downstream
+// tools can consume probe data without linking this library.
+type ProbeResult struct {
+	Device    string `json:"device"`
+	Parent    string `json:"parent,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Label     string `json:"label,omitempty"`
+	UUID      string `json:"uuid,omitempty"`
+	PartLabel string `json:"partlabel,omitempty"`
+	PartUUID  string `json:"partuuid,omitempty"`
+	PartType  string `json:"parttype,omitempty"`
+	SizeBytes uint64 `json:"size,omitempty"`
+}
+
+// All probes every known block device and partition, applying options the
+// same way GetDevWithPartitionName and GetDevWithFileSystemLabel do, and
+// returns one ProbeResult per match. Unlike those functions, it closes
+// each device once its metadata has been captured rather than returning
+// an open handle.
+func All(options ...SelectOption) ([]ProbeResult, error) {
+	devices, err := all(blockdevice.ReadonlyMode, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ProbeResult, 0, len(devices))
+
+	for _, device := range devices {
+		results = append(results, toProbeResult(device))
+
+		if cerr := device.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return results, err
+}
+
+// Marshal renders results as an indented JSON array, one record per
+// device or partition, for a `blkid`-style CLI to print.
+func Marshal(results []ProbeResult) ([]byte, error) {
+	return json.MarshalIndent(results, "", "  ")
+}
+
+func toProbeResult(device *ProbedBlockDevice) ProbeResult {
+	result := ProbeResult{
+		Device: device.Path,
+		Parent: device.Parent,
+	}
+
+	if device.SuperBlock != nil {
+		result.Type = device.SuperBlock.Type()
+		result.Label = device.SuperBlock.Label()
+		result.UUID = device.SuperBlock.UUID()
+	}
+
+	// os.Stat reports a block device's inode size as 0, so the device's
+	// capacity is read by seeking to the end of it instead.
+	if f, err := os.Open(device.Path); err == nil {
+		if size, err := f.Seek(0, io.SeekEnd); err == nil && size > 0 {
+			result.SizeBytes = uint64(size)
+		}
+
+		//nolint: errcheck
+		f.Close()
+	}
+
+	if part := findPartition(device); part != nil {
+		result.PartLabel = part.Name
+		result.PartUUID = fmt.Sprintf("%v", part.ID)
+		result.PartType = fmt.Sprintf("%v", part.Type)
+	}
+
+	return result
+}
+
+// findPartition opens device's parent disk independently of device's own
+// handle and looks up the GPT partition entry that corresponds to
+// device.Path, so its label, unique GUID and type GUID can be attached to
+// the ProbeResult. device.Path itself may be the parent disk (a filesystem
+// with no partition table), in which case there is no partition entry to
+// find.
+func findPartition(device *ProbedBlockDevice) *gpt.Partition {
+	if device.Parent == "" || device.Parent == device.Path {
+		return nil
+	}
+
+	disk, err := blockdevice.Open(device.Parent, blockdevice.WithMode(blockdevice.ReadonlyMode), blockdevice.WithSharedLock())
+	if err != nil {
+		return nil
+	}
+	//nolint: errcheck
+	defer disk.Close()
+
+	pt, err := disk.PartitionTable()
+	if err != nil {
+		return nil
+	}
+
+	diskName := filepath.Base(device.Parent)
+
+	for _, part := range pt.Partitions().Items() {
+		partpath, err := util.PartPath(diskName, int(part.Number))
+		if err != nil {
+			continue
+		}
+
+		if partpath == device.Path {
+			return part
+		}
+	}
+
+	return nil
+}
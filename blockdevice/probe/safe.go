@@ -0,0 +1,199 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package probe
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/talos-systems/go-blockdevice/blockdevice"
+	"github.com/talos-systems/go-blockdevice/blockdevice/filesystem"
+)
+
+// isHeld reports whether the device node at devpath is claimed by another
+// kernel driver (device-mapper, md, LVM, ...), as recorded under its
+// /sys/class/block/<node>/holders directory.
+func isHeld(devpath string) (bool, error) {
+	node := filepath.Base(devpath)
+
+	holders, err := ioutil.ReadDir(filepath.Join("/sys/class/block", node, "holders"))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return len(holders) > 0, nil
+}
+
+// isMounted reports whether devpath, or any of its partitions, is listed as
+// the mount source of any entry in /proc/self/mountinfo. A disk with a
+// mounted partition is just as unsafe to repartition as one mounted
+// directly, so both must be checked.
+func isMounted(devpath string) (bool, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return false, err
+	}
+	//nolint: errcheck
+	defer f.Close()
+
+	candidates := map[string]struct{}{devpath: {}}
+
+	if resolved, err := resolveDevicePath(devpath); err == nil {
+		candidates[resolved] = struct{}{}
+	}
+
+	for _, partpath := range partitionPaths(devpath) {
+		candidates[partpath] = struct{}{}
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// mountinfo fields are "... optional-fields - fstype source options";
+		// the source device is the second field after the "-" separator.
+		fields := strings.Fields(scanner.Text())
+
+		for i, field := range fields {
+			if field != "-" || i+2 >= len(fields) {
+				continue
+			}
+
+			if _, ok := candidates[fields[i+2]]; ok {
+				return true, nil
+			}
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// partitionPaths lists the /dev nodes of devpath's partitions, as recorded
+// under /sys/class/block/<node>/<node><N>/partition.
+func partitionPaths(devpath string) []string {
+	node := filepath.Base(devpath)
+
+	entries, err := ioutil.ReadDir(filepath.Join("/sys/class/block", node))
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+
+	for _, entry := range entries {
+		if _, err := os.Stat(filepath.Join("/sys/class/block", node, entry.Name(), "partition")); err != nil {
+			continue
+		}
+
+		paths = append(paths, filepath.Join("/dev", entry.Name()))
+	}
+
+	return paths
+}
+
+// WithNotHeld is a SelectOption that rejects a device claimed by another
+// kernel driver (device-mapper, md, LVM, ...), returning ErrDeviceHeld.
+func WithNotHeld() SelectOption {
+	return func(device *ProbedBlockDevice) (bool, error) {
+		held, err := isHeld(device.Device().Name())
+		if err != nil {
+			return false, err
+		}
+
+		if held {
+			return false, ErrDeviceHeld
+		}
+
+		return true, nil
+	}
+}
+
+// WithNotMounted is a SelectOption that rejects a device that is currently
+// mounted, returning ErrDeviceMounted.
+func WithNotMounted() SelectOption {
+	return func(device *ProbedBlockDevice) (bool, error) {
+		mounted, err := isMounted(device.Device().Name())
+		if err != nil {
+			return false, err
+		}
+
+		if mounted {
+			return false, ErrDeviceMounted
+		}
+
+		return true, nil
+	}
+}
+
+// SafeOpen resolves devpath - a /dev/disk/by-{label,uuid,partlabel,partuuid,id,path}
+// symlink or any other path - to its canonical device node, verifies the
+// device is neither held by another kernel driver nor currently mounted,
+// and opens it as a ProbedBlockDevice. Additional opts are applied on top
+// of those built-in safety checks, same as the matchers accepted by All.
+//
+// The held/mounted checks run on the resolved node before it is opened,
+// mirroring DevForPartitionLabel, and the returned ProbedBlockDevice is
+// resolved itself - never one of its partitions, even when resolved is a
+// whole disk with a partition table.
+func SafeOpen(devpath string, opts ...SelectOption) (*ProbedBlockDevice, error) {
+	resolved, err := resolveDevicePath(devpath)
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := isHeld(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	if held {
+		return nil, ErrDeviceHeld
+	}
+
+	mounted, err := isMounted(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	if mounted {
+		return nil, ErrDeviceMounted
+	}
+
+	bd, err := blockdevice.Open(resolved, blockdevice.WithMode(blockdevice.DefaultMode), blockdevice.WithSharedLock())
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint: errcheck
+	sb, _ := filesystem.Probe(resolved)
+
+	device := &ProbedBlockDevice{BlockDevice: bd, SuperBlock: sb, Path: resolved, Parent: resolved}
+
+	for _, matches := range opts {
+		var ok bool
+
+		ok, err = matches(device)
+		if err != nil {
+			//nolint: errcheck
+			device.Close()
+
+			return nil, err
+		}
+
+		if !ok {
+			//nolint: errcheck
+			device.Close()
+
+			return nil, os.ErrNotExist
+		}
+	}
+
+	return device, nil
+}
@@ -0,0 +1,35 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package probe
+
+import "testing"
+
+func TestIsHeldNoSysfsEntry(t *testing.T) {
+	held, err := isHeld("/dev/nonexistent-go-blockdevice-test-device")
+	if err != nil {
+		t.Fatalf("isHeld() error = %v", err)
+	}
+
+	if held {
+		t.Fatal("isHeld() = true, want false for a device with no /sys/class/block entry")
+	}
+}
+
+func TestIsMountedNoSysfsEntry(t *testing.T) {
+	mounted, err := isMounted("/dev/nonexistent-go-blockdevice-test-device")
+	if err != nil {
+		t.Fatalf("isMounted() error = %v", err)
+	}
+
+	if mounted {
+		t.Fatal("isMounted() = true, want false for a device that appears nowhere in mountinfo")
+	}
+}
+
+func TestPartitionPathsNoSysfsEntry(t *testing.T) {
+	if paths := partitionPaths("/dev/nonexistent-go-blockdevice-test-device"); paths != nil {
+		t.Fatalf("partitionPaths() = %v, want nil", paths)
+	}
+}